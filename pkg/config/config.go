@@ -10,20 +10,74 @@ import (
 
 // Config represents the configuration for the given application
 type Config struct {
-	RPCAddr            string `json:"rpc-addr" yaml:"rpc-addr"`
-	ChainID            string `json:"chain-id" yaml:"chain-id"`
-	BuildRepo          string `json:"build-repo" yaml:"build-repo"`
-	BuildCommand       string `json:"build-command" yaml:"build-command"`
-	BinaryName         string `json:"binary-name" yaml:"binary-name"`
-	BuildVersion       string `json:"build-version" yaml:"build-version"`
-	GithubAccessToken  string `json:"github-access-token" yaml:"github-access-token"`
-	RegistryRoot       string `json:"registry-root" yaml:"registry-root"`
-	RegistryRepo       string `json:"registry-repo" yaml:"registry-repo"`
-	RegistryRepoBranch string `json:"registry-repo-branch" yaml:"registry-repo-branch"`
-	GitName            string `json:"git-name" yaml:"git-name"`
-	GitEmail           string `json:"git-email" yaml:"git-email"`
-	CommitMessage      string `json:"commit-message" yaml:"commit-message"`
-	Workspace          string `json:"workspace" yaml:"workspace"`
+	RPCAddr              string              `json:"rpc-addr" yaml:"rpc-addr"`
+	RPCAddrs             []string            `json:"rpc-addrs" yaml:"rpc-addrs"`
+	ChainID              string              `json:"chain-id" yaml:"chain-id"`
+	BuildRepo            string              `json:"build-repo" yaml:"build-repo"`
+	BuildCommand         string              `json:"build-command" yaml:"build-command"`
+	BinaryName           string              `json:"binary-name" yaml:"binary-name"`
+	BuildVersion         string              `json:"build-version" yaml:"build-version"`
+	GithubAccessToken    string              `json:"github-access-token" yaml:"github-access-token"`
+	RegistryRoot         string              `json:"registry-root" yaml:"registry-root"`
+	RegistryRepo         string              `json:"registry-repo" yaml:"registry-repo"`
+	RegistryRepoBranch   string              `json:"registry-repo-branch" yaml:"registry-repo-branch"`
+	GitName              string              `json:"git-name" yaml:"git-name"`
+	GitEmail             string              `json:"git-email" yaml:"git-email"`
+	CommitMessage        string              `json:"commit-message" yaml:"commit-message"`
+	Workspace            string              `json:"workspace" yaml:"workspace"`
+	SSHKeyPath           string              `json:"ssh-key-path" yaml:"ssh-key-path"`
+	DryRun               bool                `json:"dry-run" yaml:"dry-run"`
+	SigningKeyPath       string              `json:"signing-key-path" yaml:"signing-key-path"`
+	SigningKeyPassphrase string              `json:"signing-key-passphrase" yaml:"signing-key-passphrase"`
+	SigningKeyID         string              `json:"signing-key-id" yaml:"signing-key-id"`
+	RegistrySchema       string              `json:"registry-schema" yaml:"registry-schema"`
+	RegistryRawBaseURL   string              `json:"registry-raw-base-url" yaml:"registry-raw-base-url"`
+	ChainRegistry        ChainRegistryConfig `json:"chain-registry" yaml:"chain-registry"`
+}
+
+// ChainRegistryConfig is the subset of cosmos/chain-registry metadata this
+// tool cannot discover on its own and must be supplied by the submitter:
+// display information, the bech32/slip44 identity of the chain, and its
+// declared assets.
+type ChainRegistryConfig struct {
+	PrettyName   string     `json:"pretty-name" yaml:"pretty-name"`
+	NetworkType  string     `json:"network-type" yaml:"network-type"` // mainnet, testnet, devnet
+	Bech32Prefix string     `json:"bech32-prefix" yaml:"bech32-prefix"`
+	Slip44       int        `json:"slip44" yaml:"slip44"`
+	StakingToken string     `json:"staking-token" yaml:"staking-token"`
+	FeeTokens    []FeeToken `json:"fee-tokens" yaml:"fee-tokens"`
+	Explorers    []Explorer `json:"explorers" yaml:"explorers"`
+	Assets       []Asset    `json:"assets" yaml:"assets"`
+}
+
+// FeeToken is one denom accepted for gas fees, as cosmos/chain-registry
+// expects it under chain.json's fees.fee_tokens.
+type FeeToken struct {
+	Denom            string  `json:"denom" yaml:"denom"`
+	FixedMinGasPrice float64 `json:"fixed-min-gas-price" yaml:"fixed-min-gas-price"`
+}
+
+// Explorer is one block explorer entry for chain.json's explorers list.
+type Explorer struct {
+	Kind   string `json:"kind" yaml:"kind"`
+	URL    string `json:"url" yaml:"url"`
+	TxPage string `json:"tx-page" yaml:"tx-page"`
+}
+
+// Asset is one entry of assetlist.json's assets list.
+type Asset struct {
+	Description string      `json:"description" yaml:"description"`
+	DenomUnits  []DenomUnit `json:"denom-units" yaml:"denom-units"`
+	Base        string      `json:"base" yaml:"base"`
+	Name        string      `json:"name" yaml:"name"`
+	Display     string      `json:"display" yaml:"display"`
+	Symbol      string      `json:"symbol" yaml:"symbol"`
+}
+
+// DenomUnit is one denomination/exponent pair of an Asset.
+type DenomUnit struct {
+	Denom    string `json:"denom" yaml:"denom"`
+	Exponent uint32 `json:"exponent" yaml:"exponent"`
 }
 
 // Binary returns the binary file representation from the config
@@ -38,6 +92,16 @@ func (c *Config) Binary() []byte {
 	return out
 }
 
+// RPCAddresses returns the set of RPC endpoints to cross-verify light
+// roots against. RPCAddrs takes priority; RPCAddr is kept as a
+// single-endpoint back-compat alias when RPCAddrs is unset.
+func (c *Config) RPCAddresses() []string {
+	if len(c.RPCAddrs) > 0 {
+		return c.RPCAddrs
+	}
+	return []string{c.RPCAddr}
+}
+
 // Client returns a tendermint client to work against the configured chain
 func (c *Config) Client() (*rpchttp.HTTP, error) {
 	httpClient, err := libclient.DefaultHTTPClient(c.RPCAddr)
@@ -0,0 +1,303 @@
+// Package chainregistry renders cosmos/chain-registry compatible
+// chain.json and assetlist.json files from the submitter-supplied
+// ChainRegistryConfig and the data a DumpInfo run otherwise discovers.
+package chainregistry
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	registrar "github.com/jackzampolin/cosmos-registrar/pkg/config"
+	"github.com/jackzampolin/cosmos-registrar/pkg/node/crawler"
+)
+
+// defaultSchema is used when Config.RegistrySchema is unset.
+const defaultSchema = "https://github.com/cosmos/chain-registry/blob/master/chain.schema.json"
+
+// Chain is the subset of the cosmos/chain-registry chain.json schema this
+// tool knows how to populate. These are dedicated output types (not the
+// config structs) because the schema's field names are underscored while
+// the config's are hyphenated, and "staking" is an object, not a string.
+type Chain struct {
+	Schema       string     `json:"$schema"`
+	ChainName    string     `json:"chain_name"`
+	ChainID      string     `json:"chain_id"`
+	PrettyName   string     `json:"pretty_name"`
+	NetworkType  string     `json:"network_type"`
+	Bech32Prefix string     `json:"bech32_prefix"`
+	Slip44       int        `json:"slip44"`
+	Genesis      Genesis    `json:"genesis"`
+	Codebase     Codebase   `json:"codebase"`
+	Apis         Apis       `json:"apis"`
+	Fees         Fees       `json:"fees"`
+	Staking      Staking    `json:"staking"`
+	Explorers    []Explorer `json:"explorers,omitempty"`
+}
+
+// Genesis points at the registry-hosted genesis.json for this chain.
+type Genesis struct {
+	GenesisURL string `json:"genesis_url"`
+}
+
+// Codebase is the recommended binary to run the chain.
+type Codebase struct {
+	GitRepo            string `json:"git_repo"`
+	RecommendedVersion string `json:"recommended_version"`
+}
+
+// Apis lists reachable RPC/REST endpoints, seeded from crawled peers.
+type Apis struct {
+	RPC []Endpoint `json:"rpc,omitempty"`
+}
+
+// Endpoint is a single reachable API address.
+type Endpoint struct {
+	Address string `json:"address"`
+}
+
+// Fees mirrors cosmos/chain-registry's fee token list.
+type Fees struct {
+	FeeTokens []FeeToken `json:"fee_tokens,omitempty"`
+}
+
+// FeeToken is one denom accepted for gas fees.
+type FeeToken struct {
+	Denom            string  `json:"denom"`
+	FixedMinGasPrice float64 `json:"fixed_min_gas_price"`
+}
+
+// Staking is cosmos/chain-registry's staking object: a list of tokens
+// rather than the bare denom string the config stores it as.
+type Staking struct {
+	StakingTokens []StakingToken `json:"staking_tokens"`
+}
+
+// StakingToken is one entry of Staking.StakingTokens.
+type StakingToken struct {
+	Denom string `json:"denom"`
+}
+
+// Explorer is one block explorer entry for chain.json's explorers list.
+type Explorer struct {
+	Kind   string `json:"kind"`
+	URL    string `json:"url"`
+	TxPage string `json:"tx_page"`
+}
+
+// AssetList is the cosmos/chain-registry assetlist.json schema.
+type AssetList struct {
+	Schema    string  `json:"$schema"`
+	ChainName string  `json:"chain_name"`
+	Assets    []Asset `json:"assets"`
+}
+
+// Asset is one entry of assetlist.json's assets list.
+type Asset struct {
+	Description string      `json:"description"`
+	DenomUnits  []DenomUnit `json:"denom_units"`
+	Base        string      `json:"base"`
+	Name        string      `json:"name"`
+	Display     string      `json:"display"`
+	Symbol      string      `json:"symbol"`
+}
+
+// DenomUnit is one denomination/exponent pair of an Asset.
+type DenomUnit struct {
+	Denom    string `json:"denom"`
+	Exponent uint32 `json:"exponent"`
+}
+
+// Render builds chain.json and assetlist.json for chainID from cfg's
+// ChainRegistry block plus the peers this run's crawl discovered. It
+// fails closed: a chain-registry entry missing a required field, or one
+// whose fee tokens/assets are malformed, returns an error instead of a
+// malformed document.
+func Render(chainID string, cfg *registrar.Config, discovered []crawler.Peer) (chainJSON, assetListJSON []byte, err error) {
+	if err = validate(chainID, &cfg.ChainRegistry); err != nil {
+		return nil, nil, err
+	}
+
+	schema := cfg.RegistrySchema
+	if schema == "" {
+		schema = defaultSchema
+	}
+
+	genesisURL, err := genesisURL(cfg, chainID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chain := Chain{
+		Schema:       schema,
+		ChainName:    chainID,
+		ChainID:      chainID,
+		PrettyName:   cfg.ChainRegistry.PrettyName,
+		NetworkType:  cfg.ChainRegistry.NetworkType,
+		Bech32Prefix: cfg.ChainRegistry.Bech32Prefix,
+		Slip44:       cfg.ChainRegistry.Slip44,
+		Staking:      Staking{StakingTokens: []StakingToken{{Denom: cfg.ChainRegistry.StakingToken}}},
+		Genesis:      Genesis{GenesisURL: genesisURL},
+		Codebase: Codebase{
+			GitRepo:            cfg.BuildRepo,
+			RecommendedVersion: cfg.BuildVersion,
+		},
+		Apis:      Apis{RPC: rpcEndpoints(discovered)},
+		Fees:      Fees{FeeTokens: feeTokens(cfg.ChainRegistry.FeeTokens)},
+		Explorers: explorers(cfg.ChainRegistry.Explorers),
+	}
+
+	if chainJSON, err = json.MarshalIndent(&chain, "", "  "); err != nil {
+		return nil, nil, fmt.Errorf("marshaling chain.json: %s", err)
+	}
+
+	assets := AssetList{
+		Schema:    strings.Replace(schema, "chain.schema.json", "assetlist.schema.json", 1),
+		ChainName: chainID,
+		Assets:    assetsFrom(cfg.ChainRegistry.Assets),
+	}
+	if assetListJSON, err = json.MarshalIndent(&assets, "", "  "); err != nil {
+		return nil, nil, fmt.Errorf("marshaling assetlist.json: %s", err)
+	}
+
+	return chainJSON, assetListJSON, nil
+}
+
+func feeTokens(in []registrar.FeeToken) []FeeToken {
+	out := make([]FeeToken, len(in))
+	for i, f := range in {
+		out[i] = FeeToken{Denom: f.Denom, FixedMinGasPrice: f.FixedMinGasPrice}
+	}
+	return out
+}
+
+func explorers(in []registrar.Explorer) []Explorer {
+	out := make([]Explorer, len(in))
+	for i, e := range in {
+		out[i] = Explorer{Kind: e.Kind, URL: e.URL, TxPage: e.TxPage}
+	}
+	return out
+}
+
+func assetsFrom(in []registrar.Asset) []Asset {
+	out := make([]Asset, len(in))
+	for i, a := range in {
+		units := make([]DenomUnit, len(a.DenomUnits))
+		for j, u := range a.DenomUnits {
+			units[j] = DenomUnit{Denom: u.Denom, Exponent: u.Exponent}
+		}
+		out[i] = Asset{
+			Description: a.Description,
+			DenomUnits:  units,
+			Base:        a.Base,
+			Name:        a.Name,
+			Display:     a.Display,
+			Symbol:      a.Symbol,
+		}
+	}
+	return out
+}
+
+// validate fails the run rather than letting it emit a chain-registry
+// entry missing fields wallets and explorers rely on.
+func validate(chainID string, cfg *registrar.ChainRegistryConfig) error {
+	var missing []string
+	if chainID == "" {
+		missing = append(missing, "chain-id")
+	}
+	if cfg.PrettyName == "" {
+		missing = append(missing, "chain-registry.pretty-name")
+	}
+	if cfg.NetworkType == "" {
+		missing = append(missing, "chain-registry.network-type")
+	}
+	if cfg.Bech32Prefix == "" {
+		missing = append(missing, "chain-registry.bech32-prefix")
+	}
+	if cfg.StakingToken == "" {
+		missing = append(missing, "chain-registry.staking-token")
+	}
+	for i, f := range cfg.FeeTokens {
+		if f.Denom == "" {
+			missing = append(missing, fmt.Sprintf("chain-registry.fee-tokens[%d].denom", i))
+		}
+	}
+	for i, a := range cfg.Assets {
+		if a.Base == "" {
+			missing = append(missing, fmt.Sprintf("chain-registry.assets[%d].base", i))
+		}
+		if a.Symbol == "" {
+			missing = append(missing, fmt.Sprintf("chain-registry.assets[%d].symbol", i))
+		}
+		if len(a.DenomUnits) == 0 {
+			missing = append(missing, fmt.Sprintf("chain-registry.assets[%d].denom-units", i))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("chain registry entry missing required fields: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// genesisURL points at the genesis.json this same run publishes into the
+// registry repo, so wallets can fetch it from the registry rather than
+// trusting an arbitrary RPC node. cfg.RegistryRepo is a git remote (and
+// per registry.authMethod may be an SSH form like
+// git@github.com:org/repo.git), not itself a fetchable URL, so it is
+// normalized to an https raw-content URL rather than concatenated onto.
+func genesisURL(cfg *registrar.Config, chainID string) (string, error) {
+	base := cfg.RegistryRawBaseURL
+	if base == "" {
+		var err error
+		base, err = githubRawBase(cfg.RegistryRepo, cfg.RegistryRepoBranch)
+		if err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%s/%s/genesis.json", strings.TrimSuffix(base, "/"), chainID), nil
+}
+
+// githubRawBase derives a raw.githubusercontent.com base URL from a
+// github.com git remote, in any of its https/ssh/scp-like forms.
+func githubRawBase(repo, branch string) (string, error) {
+	owner, name, err := parseGithubRepo(repo)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s", owner, name, branch), nil
+}
+
+func parseGithubRepo(repo string) (owner, name string, err error) {
+	path := strings.TrimSuffix(repo, ".git")
+	switch {
+	case strings.HasPrefix(path, "git@github.com:"):
+		path = strings.TrimPrefix(path, "git@github.com:")
+	case strings.HasPrefix(path, "ssh://git@github.com/"):
+		path = strings.TrimPrefix(path, "ssh://git@github.com/")
+	case strings.HasPrefix(path, "https://github.com/"):
+		path = strings.TrimPrefix(path, "https://github.com/")
+	case strings.HasPrefix(path, "http://github.com/"):
+		path = strings.TrimPrefix(path, "http://github.com/")
+	default:
+		return "", "", fmt.Errorf("cannot derive a raw genesis url from registry-repo %q; set registry-raw-base-url explicitly", repo)
+	}
+
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("unrecognized github registry-repo %q", repo)
+	}
+	return parts[0], parts[1], nil
+}
+
+// rpcEndpoints seeds apis.rpc from every peer the crawl could actually
+// reach over RPC.
+func rpcEndpoints(discovered []crawler.Peer) []Endpoint {
+	out := make([]Endpoint, 0, len(discovered))
+	for _, p := range discovered {
+		if p.RPCAddr == "" {
+			continue
+		}
+		out = append(out, Endpoint{Address: p.RPCAddr})
+	}
+	return out
+}
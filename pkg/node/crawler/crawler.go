@@ -0,0 +1,286 @@
+// Package crawler performs a breadth-first crawl of a Tendermint
+// network's peer-to-peer graph, starting from a seed set of RPC
+// endpoints, and liveness-checks every candidate it turns up before
+// handing back a result set fit to merge into peers.json.
+package crawler
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	tmed25519 "github.com/tendermint/tendermint/crypto/ed25519"
+	tmconn "github.com/tendermint/tendermint/p2p/conn"
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+	libclient "github.com/tendermint/tendermint/rpc/jsonrpc/client"
+	"golang.org/x/sync/errgroup"
+)
+
+// Peer is a node discovered while crawling, carrying enough metadata for
+// downstream tooling (peers.meta.json) to judge freshness and identity.
+type Peer struct {
+	ID       string    `json:"id"`
+	Addr     string    `json:"addr"` // id@remote_ip:p2p_port, ready for peers.json
+	RPCAddr  string    `json:"rpc-addr"`
+	Moniker  string    `json:"moniker"`
+	Version  string    `json:"version"`
+	LastSeen time.Time `json:"last-seen"`
+}
+
+// CrawlOptions configures a BFS crawl of the peer-to-peer network.
+type CrawlOptions struct {
+	// ChainID is the network a node must report in /status to be kept.
+	ChainID string
+	// MaxHops bounds how many /net_info hops the crawl follows from the
+	// seed set. Zero defaults to 2.
+	MaxHops int
+	// PerHopConcurrency bounds how many peers are probed concurrently
+	// within a single hop. Zero defaults to 8.
+	PerHopConcurrency int
+	// DialTimeout bounds RPC calls and the p2p liveness dial. Zero
+	// defaults to 5s.
+	DialTimeout time.Duration
+	// RPCPort is the port assumed for a discovered peer's RPC endpoint,
+	// since /net_info only advertises a peer's p2p listen port. Zero
+	// defaults to 26657, Tendermint's own default.
+	RPCPort int
+}
+
+func (o CrawlOptions) withDefaults() CrawlOptions {
+	if o.MaxHops <= 0 {
+		o.MaxHops = 2
+	}
+	if o.PerHopConcurrency <= 0 {
+		o.PerHopConcurrency = 8
+	}
+	if o.DialTimeout <= 0 {
+		o.DialTimeout = 5 * time.Second
+	}
+	if o.RPCPort <= 0 {
+		o.RPCPort = 26657
+	}
+	return o
+}
+
+// work is one (address, hop) pair waiting to be probed. id is populated
+// once a peer has been named by whoever reported it, so we can dedupe
+// against the visited set before dialing it at all.
+type work struct {
+	id   string
+	addr string
+	hop  int
+}
+
+// Crawl walks the peer-to-peer network reachable from seed up to
+// opts.MaxHops, and returns every live, chain-matching node it found with
+// its advertised p2p endpoint liveness-checked.
+func Crawl(seed []string, opts CrawlOptions) ([]Peer, error) {
+	opts = opts.withDefaults()
+
+	visited := map[string]bool{}
+	var mu sync.Mutex
+	var found []Peer
+
+	queue := make([]work, 0, len(seed))
+	for _, s := range seed {
+		queue = append(queue, work{addr: s, hop: 0})
+	}
+
+	for len(queue) > 0 {
+		hop := queue[0].hop
+		var batch []work
+		for len(queue) > 0 && queue[0].hop == hop {
+			batch = append(batch, queue[0])
+			queue = queue[1:]
+		}
+
+		var next []work
+		var nextMu sync.Mutex
+		sem := make(chan struct{}, opts.PerHopConcurrency)
+		var eg errgroup.Group
+
+		for _, w := range batch {
+			if w.id != "" {
+				mu.Lock()
+				already := visited[w.id]
+				visited[w.id] = true
+				mu.Unlock()
+				if already {
+					continue
+				}
+			}
+
+			w := w
+			sem <- struct{}{}
+			eg.Go(func() error {
+				defer func() { <-sem }()
+
+				peer, discovered, err := probeNode(w, opts)
+				if err != nil {
+					// unreachable, wrong-chain, or still-syncing nodes are
+					// simply dropped from the result set, not fatal to the crawl
+					return nil
+				}
+
+				// w.id is only known once another peer has referenced this
+				// node; a seed's real ID is learned here, from probing it.
+				// Recheck visited now so a seed that's also reachable via a
+				// peer reference in the same batch isn't probed-and-appended
+				// twice. A referenced node's ID was already claimed for this
+				// work item above, before dialing, so that claim is its own.
+				mu.Lock()
+				already := visited[peer.ID] && peer.ID != w.id
+				visited[peer.ID] = true
+				if !already {
+					found = append(found, peer)
+				}
+				mu.Unlock()
+				if already {
+					return nil
+				}
+
+				if w.hop+1 <= opts.MaxHops {
+					nextMu.Lock()
+					next = append(next, discovered...)
+					nextMu.Unlock()
+				}
+				return nil
+			})
+		}
+		if err := eg.Wait(); err != nil {
+			return nil, err
+		}
+		queue = append(queue, next...)
+	}
+
+	return probeLiveness(found, opts), nil
+}
+
+// probeNode dials w's RPC endpoint, drops it if it's off-chain or still
+// catching up, and otherwise returns it as a Peer plus the next hop's
+// work items taken from its /net_info peer list.
+func probeNode(w work, opts CrawlOptions) (Peer, []work, error) {
+	client, err := newRPCClient(w.addr, opts.DialTimeout)
+	if err != nil {
+		return Peer{}, nil, err
+	}
+
+	stat, err := client.Status()
+	switch {
+	case err != nil:
+		return Peer{}, nil, err
+	case stat.NodeInfo.Network != opts.ChainID:
+		return Peer{}, nil, fmt.Errorf("peer %s is on chain %s, not %s", w.addr, stat.NodeInfo.Network, opts.ChainID)
+	case stat.SyncInfo.CatchingUp:
+		return Peer{}, nil, fmt.Errorf("peer %s is still catching up", w.addr)
+	}
+
+	netInfo, err := client.NetInfo()
+	if err != nil {
+		return Peer{}, nil, err
+	}
+
+	peer := Peer{
+		ID:      string(stat.NodeInfo.ID()),
+		Addr:    fmt.Sprintf("%s@%s:%s", stat.NodeInfo.ID(), rpcHost(w.addr), p2pPort(stat.NodeInfo.ListenAddr)),
+		RPCAddr: w.addr,
+		Moniker: stat.NodeInfo.Moniker,
+		Version: stat.NodeInfo.Version,
+	}
+
+	next := make([]work, 0, len(netInfo.Peers))
+	for _, p := range netInfo.Peers {
+		next = append(next, work{
+			id:   string(p.NodeInfo.ID()),
+			addr: rpcAddrFor(p.RemoteIP, opts.RPCPort),
+			hop:  w.hop + 1,
+		})
+	}
+
+	return peer, next, nil
+}
+
+// probeLiveness bounds-dials each candidate's advertised p2p endpoint and
+// attempts a Tendermint secret-handshake, dropping anything unreachable.
+// Kept peers are stamped with the time they were last confirmed live.
+func probeLiveness(candidates []Peer, opts CrawlOptions) []Peer {
+	live := make([]Peer, 0, len(candidates))
+	var mu sync.Mutex
+	var eg errgroup.Group
+	sem := make(chan struct{}, opts.PerHopConcurrency)
+
+	for _, c := range candidates {
+		c := c
+		sem <- struct{}{}
+		eg.Go(func() error {
+			defer func() { <-sem }()
+			if dialP2P(c.Addr, opts.DialTimeout) {
+				c.LastSeen = time.Now()
+				mu.Lock()
+				live = append(live, c)
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+	_ = eg.Wait()
+	return live
+}
+
+// dialP2P opens a plain TCP connection to a peer's id@ip:port address and
+// attempts a Tendermint secret handshake against it using an ephemeral
+// identity. A completed TCP dial is already solid liveness evidence, so a
+// handshake rejection (e.g. the peer's own handshake timeout, or it not
+// liking our ephemeral key) does not by itself fail the probe.
+func dialP2P(addr string, timeout time.Duration) bool {
+	hostport := addr
+	if i := strings.Index(addr, "@"); i >= 0 {
+		hostport = addr[i+1:]
+	}
+
+	conn, err := net.DialTimeout("tcp", hostport, timeout)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(timeout))
+	_, _ = tmconn.MakeSecretConnection(conn, tmed25519.GenPrivKey())
+	return true
+}
+
+func newRPCClient(addr string, timeout time.Duration) (*rpchttp.HTTP, error) {
+	httpClient, err := libclient.DefaultHTTPClient(addr)
+	if err != nil {
+		return nil, err
+	}
+	httpClient.Timeout = timeout
+	return rpchttp.NewWithClient(addr, "/websocket", httpClient)
+}
+
+// rpcAddrFor derives the RPC endpoint we assume a peer exposes, since
+// /net_info only tells us its IP, not its RPC port.
+func rpcAddrFor(ip string, rpcPort int) string {
+	return fmt.Sprintf("http://%s:%d", ip, rpcPort)
+}
+
+// rpcHost extracts the bare host from an http(s):// RPC address.
+func rpcHost(addr string) string {
+	host := addr
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.LastIndex(host, ":"); i >= 0 {
+		host = host[:i]
+	}
+	return host
+}
+
+// p2pPort pulls the port out of a self-reported listen address such as
+// "tcp://0.0.0.0:26656".
+func p2pPort(listenAddr string) string {
+	parts := strings.Split(listenAddr, ":")
+	return parts[len(parts)-1]
+}
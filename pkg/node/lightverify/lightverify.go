@@ -0,0 +1,255 @@
+// Package lightverify removes the single-point-of-trust in trusting one
+// RPC endpoint's /commit response: it cross-checks the commit at a given
+// height across every configured endpoint and, once a prior trust root is
+// available, runs real tendermint light-client verification against it.
+package lightverify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	libclient "github.com/tendermint/tendermint/rpc/jsonrpc/client"
+
+	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/light"
+	lightprovider "github.com/tendermint/tendermint/light/provider"
+	lighthttp "github.com/tendermint/tendermint/light/provider/http"
+	lightstore "github.com/tendermint/tendermint/light/store/db"
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+	dbm "github.com/tendermint/tm-db"
+	"golang.org/x/sync/errgroup"
+)
+
+// defaultTrustPeriod mirrors the unbonding period used across
+// cosmos-sdk chains; a light root older than this can no longer be
+// trusted for skipping verification.
+const defaultTrustPeriod = 504 * time.Hour // 3 weeks
+
+// TrustedRoot is the previously published light root a new height is
+// verified against.
+type TrustedRoot struct {
+	Height int64
+	Hash   []byte
+}
+
+// Options configures a single verification run.
+type Options struct {
+	// Height is the block height being verified.
+	Height int64
+	// Quorum is the minimum number of endpoints that must agree on the
+	// header and commit hash at Height. Zero means every endpoint that
+	// responds must agree, and at least two when more than one is
+	// configured.
+	Quorum int
+	// TrustPeriod bounds how long a TrustedRoot stays usable. Zero means
+	// defaultTrustPeriod.
+	TrustPeriod time.Duration
+}
+
+// EndpointResult is one RPC endpoint's view of the commit at a height.
+type EndpointResult struct {
+	Addr       string
+	HeaderHash string
+	CommitHash string
+	Err        error
+}
+
+// QuorumCommit fetches the commit at height from every addr, requires
+// opts.Quorum of them to agree on the header/commit hash (default: all
+// that respond, see CheckQuorum),
+// and returns the full commit from a reachable, agreeing endpoint. Use
+// this to bootstrap the very first trust root, where there is no prior
+// root to light-client-verify against yet.
+func QuorumCommit(ctx context.Context, addrs []string, opts Options, logger log.Logger) (*ctypes.ResultCommit, error) {
+	results := fetchCommits(ctx, addrs, opts.Height)
+	for _, r := range results {
+		if r.Err != nil {
+			logger.Info("endpoint unreachable", "addr", r.Addr, "err", r.Err)
+		}
+	}
+
+	agreedHash, _, err := CheckQuorum(results, opts.Quorum)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range results {
+		if r.Err == nil && r.HeaderHash == agreedHash {
+			client, cerr := newClient(r.Addr)
+			if cerr != nil {
+				continue
+			}
+			h := opts.Height
+			return client.Commit(&h)
+		}
+	}
+	return nil, fmt.Errorf("no reachable, quorum-agreeing endpoint to fetch commit from")
+}
+
+// VerifyRoot cross-checks the commit at opts.Height across addrs and,
+// once quorum holds, runs tendermint light-client verification of that
+// height against trusted, using addrs[0] as primary and the rest as
+// witnesses. It fails closed: any fork between endpoints, or a light
+// client verification failure (including trust period expiry or a
+// skipping-verification gap it cannot bridge), aborts the run.
+func VerifyRoot(ctx context.Context, chainID string, addrs []string, trusted TrustedRoot, opts Options, logger log.Logger) (*ctypes.ResultCommit, error) {
+	results := fetchCommits(ctx, addrs, opts.Height)
+	for _, r := range results {
+		if r.Err != nil {
+			logger.Info("endpoint unreachable", "addr", r.Addr, "err", r.Err)
+		}
+	}
+
+	if _, _, err := CheckQuorum(results, opts.Quorum); err != nil {
+		return nil, err
+	}
+
+	lb, err := verifyWithLightClient(ctx, chainID, addrs, trusted, opts, logger)
+	if err != nil {
+		return nil, fmt.Errorf("light client verification: %s", err)
+	}
+
+	return &ctypes.ResultCommit{SignedHeader: *lb.SignedHeader}, nil
+}
+
+// CheckQuorum tallies endpoint results by (header hash, commit hash) pair
+// and returns the pair that reached quorum. Unreachable endpoints are
+// ignored; if no pair reaches quorum this reports the observed
+// divergence so a fork can be diagnosed per endpoint.
+func CheckQuorum(results []EndpointResult, quorum int) (headerHash, commitHash string, err error) {
+	reachable := make([]EndpointResult, 0, len(results))
+	for _, r := range results {
+		if r.Err == nil {
+			reachable = append(reachable, r)
+		}
+	}
+	if len(reachable) == 0 {
+		return "", "", fmt.Errorf("no endpoint returned a commit")
+	}
+
+	// Default quorum is "all endpoints must agree" — but that must mean
+	// all endpoints that actually responded, not all configured ones.
+	// Otherwise an unrelated endpoint outage (not a fork) looks
+	// identical to a fork and aborts the run, which makes configuring
+	// more RPCAddrs for resilience actively more likely to fail.
+	if quorum <= 0 {
+		quorum = len(reachable)
+	}
+	// Never let a mass outage collapse the default quorum down to a
+	// single endpoint: if more than one endpoint is configured, at least
+	// two of them must actually agree, or this degrades back into
+	// trusting one unverified endpoint — the single-point-of-trust this
+	// whole package exists to remove.
+	if len(results) > 1 && quorum < 2 {
+		quorum = 2
+	}
+
+	tally := map[string]int{}
+	for _, r := range reachable {
+		tally[r.HeaderHash+"|"+r.CommitHash]++
+	}
+
+	var winner string
+	var winnerCount int
+	for k, c := range tally {
+		if c > winnerCount {
+			winner, winnerCount = k, c
+		}
+	}
+
+	if winnerCount < quorum {
+		return "", "", fmt.Errorf("fork detected: best agreement %d/%d endpoints, wanted quorum %d: %s",
+			winnerCount, len(results), quorum, describeDivergence(reachable))
+	}
+
+	parts := strings.SplitN(winner, "|", 2)
+	return parts[0], parts[1], nil
+}
+
+func describeDivergence(results []EndpointResult) string {
+	parts := make([]string, 0, len(results))
+	for _, r := range results {
+		parts = append(parts, fmt.Sprintf("%s=%s/%s", r.Addr, r.HeaderHash, r.CommitHash))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func fetchCommits(ctx context.Context, addrs []string, height int64) []EndpointResult {
+	results := make([]EndpointResult, len(addrs))
+	var eg errgroup.Group
+	for i, addr := range addrs {
+		i, addr := i, addr
+		eg.Go(func() error {
+			client, err := newClient(addr)
+			if err != nil {
+				results[i] = EndpointResult{Addr: addr, Err: err}
+				return nil
+			}
+			h := height
+			commit, err := client.Commit(&h)
+			if err != nil {
+				results[i] = EndpointResult{Addr: addr, Err: err}
+				return nil
+			}
+			results[i] = EndpointResult{
+				Addr:       addr,
+				HeaderHash: commit.SignedHeader.Header.Hash().String(),
+				CommitHash: commit.SignedHeader.Commit.BlockID.Hash.String(),
+			}
+			return nil
+		})
+	}
+	_ = eg.Wait()
+	return results
+}
+
+func verifyWithLightClient(ctx context.Context, chainID string, addrs []string, trusted TrustedRoot, opts Options, logger log.Logger) (*tmtypes.LightBlock, error) {
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no rpc endpoints configured")
+	}
+
+	providers := make([]lightprovider.Provider, len(addrs))
+	for i, addr := range addrs {
+		providers[i] = lighthttp.New(chainID, addr)
+	}
+
+	trustPeriod := opts.TrustPeriod
+	if trustPeriod == 0 {
+		trustPeriod = defaultTrustPeriod
+	}
+
+	c, err := light.NewClient(
+		ctx,
+		chainID,
+		light.TrustOptions{
+			Period: trustPeriod,
+			Height: trusted.Height,
+			Hash:   trusted.Hash,
+		},
+		providers[0],
+		providers[1:],
+		lightstore.New(dbm.NewMemDB(), chainID),
+		light.Logger(logger),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("initializing light client: %s", err)
+	}
+
+	lb, err := c.VerifyLightBlockAtHeight(ctx, opts.Height, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("verifying light block at height %d: %s", opts.Height, err)
+	}
+	return lb, nil
+}
+
+func newClient(addr string) (*rpchttp.HTTP, error) {
+	httpClient, err := libclient.DefaultHTTPClient(addr)
+	if err != nil {
+		return nil, err
+	}
+	return rpchttp.NewWithClient(addr, "/websocket", httpClient)
+}
@@ -0,0 +1,156 @@
+package node
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	registrar "github.com/jackzampolin/cosmos-registrar/pkg/config"
+	"github.com/tendermint/tendermint/libs/log"
+	"golang.org/x/crypto/openpgp"
+)
+
+// Manifest records every file a DumpInfo run touched, alongside enough
+// context (chain, trust height, submitter) for a reviewer or Verify to
+// audit the update without having to trust the process that produced it.
+type Manifest struct {
+	ChainID     string            `json:"chain-id"`
+	TrustHeight int64             `json:"trust-height"`
+	Submitter   string            `json:"submitter"`
+	Files       map[string]string `json:"files"` // path relative to the chain dir -> sha256
+}
+
+func (r repoDir) manifestPath() string    { return path.Join(r.chainPath(), "manifest.json") }
+func (r repoDir) manifestSigPath() string { return path.Join(r.chainPath(), "manifest.json.sig") }
+
+// MaintainersPath returns the path of the keyring of maintainer keys
+// shipped alongside a chain's registry entry.
+func MaintainersPath(base, chainID string) string {
+	return path.Join(repoDir{base, chainID}.chainPath(), "maintainers.asc")
+}
+
+// collectTouchedFiles walks a chain directory and returns every regular
+// file in it except the manifest and keyring themselves.
+func collectTouchedFiles(rdir repoDir) (files []string, err error) {
+	err = filepath.Walk(rdir.chainPath(), func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		switch path.Base(p) {
+		case "manifest.json", "manifest.json.sig", "maintainers.asc":
+			return nil
+		}
+		files = append(files, p)
+		return nil
+	})
+	return
+}
+
+// writeManifest hashes every touched file, writes manifest.json, and
+// signs it with the PGP key configured on config, producing a detached
+// manifest.json.sig next to it.
+func writeManifest(rdir repoDir, chainID string, trustHeight int64, config *registrar.Config, touched []string, logger log.Logger) error {
+	m := Manifest{
+		ChainID:     chainID,
+		TrustHeight: trustHeight,
+		Submitter:   fmt.Sprintf("%s <%s>", config.GitName, config.GitEmail),
+		Files:       map[string]string{},
+	}
+
+	for _, p := range touched {
+		sum, err := sha256File(p)
+		if err != nil {
+			return fmt.Errorf("hashing %s: %s", p, err)
+		}
+		rel, err := filepath.Rel(rdir.chainPath(), p)
+		if err != nil {
+			return fmt.Errorf("relativizing %s: %s", p, err)
+		}
+		m.Files[rel] = sum
+	}
+
+	out, err := json.MarshalIndent(&m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling manifest: %s", err)
+	}
+	if err = writeFile(rdir.manifestPath(), out, logger); err != nil {
+		return err
+	}
+
+	sig, err := signManifest(out, config)
+	if err != nil {
+		return fmt.Errorf("signing manifest: %s", err)
+	}
+	return writeFile(rdir.manifestSigPath(), sig, logger)
+}
+
+func sha256File(pth string) (string, error) {
+	b, err := ioutil.ReadFile(pth)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b)), nil
+}
+
+// signManifest produces a detached, armored PGP signature over payload
+// using the key at config.SigningKeyPath, optionally narrowed to
+// config.SigningKeyID and decrypted with config.SigningKeyPassphrase.
+func signManifest(payload []byte, config *registrar.Config) ([]byte, error) {
+	if config.SigningKeyPath == "" {
+		return nil, fmt.Errorf("no signing-key-path configured")
+	}
+
+	f, err := os.Open(config.SigningKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening signing key: %s", err)
+	}
+	defer f.Close()
+
+	keyring, err := openpgp.ReadArmoredKeyRing(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading signing key: %s", err)
+	}
+
+	entity, err := selectEntity(keyring, config.SigningKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if entity.PrivateKey.Encrypted {
+		if config.SigningKeyPassphrase == "" {
+			return nil, fmt.Errorf("signing key %s is passphrase protected", entity.PrivateKey.KeyIdShortString())
+		}
+		if err = entity.PrivateKey.Decrypt([]byte(config.SigningKeyPassphrase)); err != nil {
+			return nil, fmt.Errorf("decrypting signing key: %s", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err = openpgp.ArmoredDetachSign(&buf, entity, bytes.NewReader(payload), nil); err != nil {
+		return nil, fmt.Errorf("detached sign: %s", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func selectEntity(keyring openpgp.EntityList, keyID string) (*openpgp.Entity, error) {
+	if len(keyring) == 0 {
+		return nil, fmt.Errorf("empty keyring")
+	}
+	if keyID == "" {
+		return keyring[0], nil
+	}
+	for _, e := range keyring {
+		if e.PrimaryKey != nil && (e.PrimaryKey.KeyIdString() == keyID || fmt.Sprintf("%X", e.PrimaryKey.Fingerprint) == keyID) {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("signing-key-id %s not found in keyring", keyID)
+}
@@ -1,15 +1,19 @@
 package node
 
 import (
+	"context"
 	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
-	"strings"
 
 	registrar "github.com/jackzampolin/cosmos-registrar/pkg/config"
+	"github.com/jackzampolin/cosmos-registrar/pkg/node/chainregistry"
+	"github.com/jackzampolin/cosmos-registrar/pkg/node/crawler"
+	"github.com/jackzampolin/cosmos-registrar/pkg/node/lightverify"
 	"github.com/tendermint/tendermint/libs/log"
 	ctypes "github.com/tendermint/tendermint/rpc/core/types"
 	tmtypes "github.com/tendermint/tendermint/types"
@@ -19,7 +23,7 @@ import (
 var (
 	gen     *ctypes.ResultGenesis
 	commit  *ctypes.ResultCommit
-	netInfo *ctypes.ResultNetInfo
+	crawled []crawler.Peer
 	rdir    repoDir
 	eg      errgroup.Group
 )
@@ -53,25 +57,38 @@ func DumpInfo(base, chainID string, config *registrar.Config, logger log.Logger)
 		return nil
 	})
 
+	rdir = repoDir{base, chainID}
+
 	eg.Go(func() error {
 		h := stat.SyncInfo.LatestBlockHeight
-		commit, err = client.Commit(&h)
+		opts := lightverify.Options{Height: h}
+		addrs := config.RPCAddresses()
+
+		trusted, hasTrusted, terr := loadTrustedRoot(rdir.latestPath())
+		if terr != nil {
+			return fmt.Errorf("loading trusted light root: %s", terr)
+		}
+
+		if hasTrusted {
+			commit, err = lightverify.VerifyRoot(context.Background(), chainID, addrs, trusted, opts, logger)
+		} else {
+			logger.Info("no prior trust root, bootstrapping from quorum-checked commit", "height", h)
+			commit, err = lightverify.QuorumCommit(context.Background(), addrs, opts, logger)
+		}
 		if err != nil {
 			return fmt.Errorf("commit: %s", err)
 		}
-		logger.Info(fmt.Sprintf("GET /commit?height=%d", h), "rpc-addr", config.RPCAddr)
+		logger.Info(fmt.Sprintf("GET /commit?height=%d", h), "rpc-addrs", addrs)
 		return nil
 	})
 
-	// TODO: in a more advanced version of this tool,
-	// this would crawl the network a couple of hops
-	// and find more peers
 	eg.Go(func() error {
-		netInfo, err = client.NetInfo()
-		if err != nil {
-			return fmt.Errorf("net-info: %s", err)
+		var cerr error
+		crawled, cerr = crawler.Crawl(config.RPCAddresses(), crawler.CrawlOptions{ChainID: chainID})
+		if cerr != nil {
+			return fmt.Errorf("crawling peers: %s", cerr)
 		}
-		logger.Info("GET /net_info", "rpc-addr", config.RPCAddr)
+		logger.Info("crawled peer network", "found", len(crawled))
 		return nil
 	})
 
@@ -79,7 +96,6 @@ func DumpInfo(base, chainID string, config *registrar.Config, logger log.Logger)
 		return fmt.Errorf("fetching: %s", err)
 	}
 	// fetch data
-	rdir := repoDir{base, chainID}
 	if err = createDirIfNotExist(rdir.chainPath(), logger); err != nil {
 		return
 	}
@@ -107,14 +123,17 @@ func DumpInfo(base, chainID string, config *registrar.Config, logger log.Logger)
 		return nil
 	})
 	eg.Go(func() error {
-		qp := stringsFromPeers(netInfo.Peers)
+		qp := addrsFromCrawled(crawled)
 		if _, err = os.Stat(rdir.peersPath()); os.IsNotExist(err) {
-			logger.Info("no peers file, popoulating from /net_info", "num", len(qp))
+			logger.Info("no peers file, popoulating from crawl", "num", len(qp))
 			out, err := json.MarshalIndent(qp, "", "  ")
 			if err != nil {
 				return fmt.Errorf("marshaling peers: %s", err)
 			}
-			return writeFile(rdir.peersPath(), out, logger)
+			if err = writeFile(rdir.peersPath(), out, logger); err != nil {
+				return err
+			}
+			return writeFile(rdir.peersMetaPath(), marshalPeersMeta(crawled), logger)
 		}
 
 		var fp []string
@@ -133,18 +152,42 @@ func DumpInfo(base, chainID string, config *registrar.Config, logger log.Logger)
 		}
 		pf.Close()
 		ps := dedupe(append(fp, qp...))
-		// TODO: we should check peer liveness here
 		logger.Info(fmt.Sprintf("added %d new peers to %s", len(ps)-len(fp), path.Base(rdir.peersPath())))
 		w, err := json.MarshalIndent(ps, "", "  ")
 		if err != nil {
 			return fmt.Errorf("marshaling peers: %s", err)
 		}
-		return updateFile(rdir.peersPath(), w, logger)
+		if err = updateFile(rdir.peersPath(), w, logger); err != nil {
+			return err
+		}
+		return updateFile(rdir.peersMetaPath(), marshalPeersMeta(crawled), logger)
+	})
+	eg.Go(func() error {
+		if config.ChainRegistry.PrettyName == "" {
+			logger.Info("chain-registry not configured, skipping chain.json/assetlist.json", "chain-id", chainID)
+			return nil
+		}
+		chainJSON, assetListJSON, rerr := chainregistry.Render(chainID, config, crawled)
+		if rerr != nil {
+			return fmt.Errorf("rendering chain registry files: %s", rerr)
+		}
+		if err := writeFile(rdir.chainRegistryPath(), chainJSON, logger); err != nil {
+			return err
+		}
+		return writeFile(rdir.assetListPath(), assetListJSON, logger)
 	})
 
 	if err = eg.Wait(); err != nil {
 		return err
 	}
+
+	touched, err := collectTouchedFiles(rdir)
+	if err != nil {
+		return fmt.Errorf("collecting touched files: %s", err)
+	}
+	if err = writeManifest(rdir, chainID, commit.SignedHeader.Header.Height, config, touched, logger); err != nil {
+		return fmt.Errorf("writing manifest: %s", err)
+	}
 	return
 }
 
@@ -161,6 +204,9 @@ func (r repoDir) latestPath() string        { return path.Join(r.lrpath(), "late
 func (r repoDir) heightPath(h int64) string { return path.Join(r.lrpath(), fmt.Sprintf("%d.json", h)) }
 func (r repoDir) binariesPath() string      { return path.Join(r.chainPath(), "binaries.json") }
 func (r repoDir) peersPath() string         { return path.Join(r.chainPath(), "peers.json") }
+func (r repoDir) peersMetaPath() string     { return path.Join(r.chainPath(), "peers.meta.json") }
+func (r repoDir) chainRegistryPath() string { return path.Join(r.chainPath(), "chain.json") }
+func (r repoDir) assetListPath() string     { return path.Join(r.chainPath(), "assetlist.json") }
 
 func updateFileGo(pth string, payload []byte, log log.Logger) func() error {
 	return func() (err error) {
@@ -192,14 +238,24 @@ func createDirIfNotExist(pth string, log log.Logger) (err error) {
 	return nil
 }
 
-func stringsFromPeers(ni []ctypes.Peer) (qp []string) {
-	for _, p := range ni {
-		port := strings.Split(p.NodeInfo.ListenAddr, ":")
-		qp = append(qp, fmt.Sprintf("%s@%s:%s", p.NodeInfo.ID(), p.RemoteIP, port[len(port)-1]))
+func addrsFromCrawled(cs []crawler.Peer) (qp []string) {
+	for _, c := range cs {
+		qp = append(qp, c.Addr)
 	}
 	return
 }
 
+// marshalPeersMeta renders the crawl's moniker/version/last-seen data,
+// keyed by node ID, for peers.meta.json.
+func marshalPeersMeta(cs []crawler.Peer) []byte {
+	meta := make(map[string]crawler.Peer, len(cs))
+	for _, c := range cs {
+		meta[c.ID] = c
+	}
+	out, _ := json.MarshalIndent(meta, "", "  ")
+	return out
+}
+
 func sortedGenesis(gen *tmtypes.GenesisDoc) (sum string, indented []byte, err error) {
 	// prepare to sort
 	if indented, err = json.Marshal(gen); err != nil {
@@ -238,6 +294,31 @@ func NewLightRoot(sh tmtypes.SignedHeader) []byte {
 	return out
 }
 
+// loadTrustedRoot reads a previously published light root (latest.json)
+// to use as the trust anchor for lightverify.VerifyRoot. A missing file
+// is not an error: it means this is the chain's first submission and
+// there is nothing yet to verify against.
+func loadTrustedRoot(pth string) (root lightverify.TrustedRoot, ok bool, err error) {
+	b, err := ioutil.ReadFile(pth)
+	if os.IsNotExist(err) {
+		return lightverify.TrustedRoot{}, false, nil
+	}
+	if err != nil {
+		return lightverify.TrustedRoot{}, false, err
+	}
+
+	var lr LightRoot
+	if err = json.Unmarshal(b, &lr); err != nil {
+		return lightverify.TrustedRoot{}, false, fmt.Errorf("unmarshaling %s: %s", pth, err)
+	}
+
+	hash, err := hex.DecodeString(lr.TrustHash)
+	if err != nil {
+		return lightverify.TrustedRoot{}, false, fmt.Errorf("decoding trust hash %s: %s", lr.TrustHash, err)
+	}
+	return lightverify.TrustedRoot{Height: lr.TrustHeight, Hash: hash}, true, nil
+}
+
 func dedupe(ele []string) (out []string) {
 	e := map[string]bool{}
 	for v := range ele {
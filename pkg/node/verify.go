@@ -0,0 +1,82 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// LoadKeyring reads an armored PGP public keyring from pth, such as the
+// maintainers.asc file shipped inside a chain's registry directory.
+func LoadKeyring(pth string) (openpgp.EntityList, error) {
+	f, err := os.Open(pth)
+	if err != nil {
+		return nil, fmt.Errorf("opening keyring %s: %s", pth, err)
+	}
+	defer f.Close()
+
+	keyring, err := openpgp.ReadArmoredKeyRing(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading keyring %s: %s", pth, err)
+	}
+	return keyring, nil
+}
+
+// Verify walks the chain directory under base, recomputes the sha256 of
+// every file listed in its manifest, and checks manifest.json.sig against
+// keyring. It rejects the update if the manifest is unsigned, signed by a
+// key not in keyring, any recorded hash no longer matches the file on
+// disk, or the manifest's recorded genesis.json hash disagrees with
+// genesis.json.sum.
+func Verify(base, chainID string, keyring openpgp.EntityList) error {
+	rdir := repoDir{base, chainID}
+
+	raw, err := ioutil.ReadFile(rdir.manifestPath())
+	if err != nil {
+		return fmt.Errorf("reading manifest: %s", err)
+	}
+
+	sigFile, err := os.Open(rdir.manifestSigPath())
+	if err != nil {
+		return fmt.Errorf("manifest is unsigned: %s", err)
+	}
+	defer sigFile.Close()
+
+	if _, err = openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(raw), sigFile); err != nil {
+		return fmt.Errorf("manifest signed by unknown key: %s", err)
+	}
+
+	var m Manifest
+	if err = json.Unmarshal(raw, &m); err != nil {
+		return fmt.Errorf("unmarshaling manifest: %s", err)
+	}
+	if m.ChainID != chainID {
+		return fmt.Errorf("manifest chain-id %s does not match %s", m.ChainID, chainID)
+	}
+
+	for rel, wantSum := range m.Files {
+		gotSum, err := sha256File(path.Join(rdir.chainPath(), rel))
+		if err != nil {
+			return fmt.Errorf("hashing %s: %s", rel, err)
+		}
+		if gotSum != wantSum {
+			return fmt.Errorf("%s hash mismatch: manifest says %s, found %s", rel, wantSum, gotSum)
+		}
+	}
+
+	genesisSum, err := ioutil.ReadFile(rdir.genesisSumPath())
+	if err != nil {
+		return fmt.Errorf("reading genesis.json.sum: %s", err)
+	}
+	if sum, ok := m.Files["genesis.json"]; ok && strings.TrimSpace(string(genesisSum)) != sum {
+		return fmt.Errorf("genesis.json.sum disagrees with manifest: %s != %s", strings.TrimSpace(string(genesisSum)), sum)
+	}
+
+	return nil
+}
@@ -0,0 +1,291 @@
+// Package registry drives the local git checkout of the registry repo:
+// cloning or updating it, running the node dump against its working tree,
+// and committing and pushing the result upstream.
+package registry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+
+	registrar "github.com/jackzampolin/cosmos-registrar/pkg/config"
+	"github.com/jackzampolin/cosmos-registrar/pkg/node"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+const (
+	remoteName  = "origin"
+	pushRetries = 3
+	pushBackoff = 2 * time.Second
+)
+
+// Sync brings config.Workspace up to date with RegistryRepo at
+// RegistryRepoBranch, runs node.DumpInfo against the resulting working
+// tree, and commits and pushes whatever changed under <chainID>/.
+//
+// When config.DryRun is set the working tree is left dirty (staged but
+// not committed or pushed) so the diff can be inspected by hand.
+func Sync(ctx context.Context, cfg *registrar.Config, chainID string, logger log.Logger) error {
+	auth, err := authMethod(cfg)
+	if err != nil {
+		return fmt.Errorf("resolving git auth: %s", err)
+	}
+
+	repo, err := cloneOrUpdate(ctx, cfg, auth, logger)
+	if err != nil {
+		return fmt.Errorf("preparing workspace: %s", err)
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("worktree: %s", err)
+	}
+
+	if err = node.DumpInfo(cfg.Workspace, chainID, cfg, logger); err != nil {
+		return fmt.Errorf("dumping node info: %s", err)
+	}
+
+	if err = wt.AddWithOptions(&git.AddOptions{Path: chainID}); err != nil {
+		return fmt.Errorf("staging %s: %s", chainID, err)
+	}
+
+	status, err := wt.Status()
+	if err != nil {
+		return fmt.Errorf("git status: %s", err)
+	}
+	if status.IsClean() {
+		logger.Info("nothing changed, skipping commit", "chain-id", chainID)
+		return nil
+	}
+
+	if cfg.DryRun {
+		logger.Info("dry-run: leaving workspace dirty for inspection", "workspace", cfg.Workspace)
+		return nil
+	}
+
+	if err = verifyManifest(cfg.Workspace, chainID, logger); err != nil {
+		return fmt.Errorf("verifying manifest before commit: %s", err)
+	}
+
+	commitMsg := cfg.CommitMessage
+	if commitMsg == "" {
+		commitMsg = fmt.Sprintf("update %s", chainID)
+	}
+	sig := &object.Signature{
+		Name:  cfg.GitName,
+		Email: cfg.GitEmail,
+		When:  time.Now(),
+	}
+	if _, err = wt.Commit(commitMsg, &git.CommitOptions{Author: sig}); err != nil {
+		return fmt.Errorf("committing: %s", err)
+	}
+	logger.Info("committed registry update", "chain-id", chainID)
+
+	return pushWithRetry(ctx, repo, wt, cfg, chainID, auth, logger)
+}
+
+// verifyManifest checks the manifest.json that DumpInfo just produced
+// against the maintainers keyring shipped in the chain's registry entry,
+// before we let git commit it. A chain that hasn't published a keyring
+// yet (first submission) is allowed through with a warning.
+func verifyManifest(base, chainID string, logger log.Logger) error {
+	keyringPath := node.MaintainersPath(base, chainID)
+	if _, err := os.Stat(keyringPath); os.IsNotExist(err) {
+		logger.Info("no maintainers keyring published yet, skipping manifest verification", "chain-id", chainID)
+		return nil
+	}
+
+	keyring, err := node.LoadKeyring(keyringPath)
+	if err != nil {
+		return fmt.Errorf("loading maintainers keyring: %s", err)
+	}
+	return node.Verify(base, chainID, keyring)
+}
+
+// cloneOrUpdate ensures cfg.Workspace holds a checkout of RegistryRepo at
+// RegistryRepoBranch, cloning it if it doesn't exist yet or fetching and
+// fast-forwarding it otherwise.
+func cloneOrUpdate(ctx context.Context, cfg *registrar.Config, auth transport.AuthMethod, logger log.Logger) (*git.Repository, error) {
+	branch := plumbing.NewBranchReferenceName(cfg.RegistryRepoBranch)
+
+	if _, err := os.Stat(cfg.Workspace); os.IsNotExist(err) {
+		logger.Info("cloning registry repo", "repo", cfg.RegistryRepo, "branch", cfg.RegistryRepoBranch, "workspace", cfg.Workspace)
+		return git.PlainCloneContext(ctx, cfg.Workspace, false, &git.CloneOptions{
+			URL:           cfg.RegistryRepo,
+			ReferenceName: branch,
+			SingleBranch:  true,
+			Auth:          auth,
+		})
+	}
+
+	repo, err := git.PlainOpen(cfg.Workspace)
+	if err != nil {
+		return nil, fmt.Errorf("opening existing workspace: %s", err)
+	}
+
+	if err = fastForward(ctx, repo, branch, auth, logger); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// fastForward fetches origin and fast-forwards the checked out branch onto
+// its remote-tracking ref.
+func fastForward(ctx context.Context, repo *git.Repository, branch plumbing.ReferenceName, auth transport.AuthMethod, logger log.Logger) error {
+	wt, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("worktree: %s", err)
+	}
+
+	err = wt.PullContext(ctx, &git.PullOptions{
+		RemoteName:    remoteName,
+		ReferenceName: branch,
+		SingleBranch:  true,
+		Auth:          auth,
+	})
+	switch err {
+	case nil, git.NoErrAlreadyUpToDate:
+		logger.Info("workspace up to date", "branch", branch.Short())
+		return nil
+	default:
+		return fmt.Errorf("fetching+fast-forwarding: %s", err)
+	}
+}
+
+// pushWithRetry pushes the current branch, and on a non-fast-forward
+// rejection rebases the local commit onto the new remote HEAD and retries.
+func pushWithRetry(ctx context.Context, repo *git.Repository, wt *git.Worktree, cfg *registrar.Config, chainID string, auth transport.AuthMethod, logger log.Logger) error {
+	branch := plumbing.NewBranchReferenceName(cfg.RegistryRepoBranch)
+
+	var err error
+	for attempt := 1; attempt <= pushRetries; attempt++ {
+		err = repo.PushContext(ctx, &git.PushOptions{RemoteName: remoteName, Auth: auth})
+		switch err {
+		case nil, git.NoErrAlreadyUpToDate:
+			logger.Info("pushed registry update", "branch", branch.Short(), "attempt", attempt)
+			return nil
+		case git.ErrNonFastForwardUpdate:
+			logger.Info("push rejected, rebasing onto remote HEAD", "attempt", attempt)
+			if rerr := rebaseOntoRemote(ctx, repo, wt, branch, chainID, auth, logger); rerr != nil {
+				return fmt.Errorf("rebasing after rejected push: %s", rerr)
+			}
+			time.Sleep(pushBackoff * time.Duration(attempt))
+			continue
+		default:
+			return fmt.Errorf("pushing: %s", err)
+		}
+	}
+	return fmt.Errorf("pushing after %d attempts: %s", pushRetries, err)
+}
+
+// rebaseOntoRemote replays the local HEAD commit on top of the remote's
+// branch head. go-git has no native rebase, so this resets to the fetched
+// remote ref and cherry-picks the local commit's tree on top of it.
+//
+// The local commit's tree is based on the old remote HEAD, so it holds
+// stale copies of every other chain's files alongside chainID's. Only
+// chainID's subtree is replayed and staged — replaying the full tree
+// would silently revert whatever other chains' updates just landed on
+// the new remote HEAD.
+func rebaseOntoRemote(ctx context.Context, repo *git.Repository, wt *git.Worktree, branch plumbing.ReferenceName, chainID string, auth transport.AuthMethod, logger log.Logger) error {
+	local, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("reading local head: %s", err)
+	}
+	localCommit, err := repo.CommitObject(local.Hash())
+	if err != nil {
+		return fmt.Errorf("reading local commit: %s", err)
+	}
+
+	if err = repo.FetchContext(ctx, &git.FetchOptions{RemoteName: remoteName, Auth: auth}); err != nil && err != git.NoErrAlreadyUpToDate {
+		return fmt.Errorf("fetching remote: %s", err)
+	}
+
+	remoteRef, err := repo.Reference(plumbing.NewRemoteReferenceName(remoteName, branch.Short()), true)
+	if err != nil {
+		return fmt.Errorf("resolving remote ref: %s", err)
+	}
+
+	if err = wt.Reset(&git.ResetOptions{Commit: remoteRef.Hash(), Mode: git.HardReset}); err != nil {
+		return fmt.Errorf("resetting onto remote head: %s", err)
+	}
+
+	// replay only chainID's subtree of the local commit on top of the
+	// rebased HEAD
+	localTree, err := localCommit.Tree()
+	if err != nil {
+		return fmt.Errorf("reading local tree: %s", err)
+	}
+	chainTree, err := localTree.Tree(chainID)
+	if err != nil {
+		return fmt.Errorf("reading local %s subtree: %s", chainID, err)
+	}
+	walker := object.NewTreeWalker(chainTree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, werr := walker.Next()
+		if werr == io.EOF {
+			break
+		}
+		if werr != nil {
+			return fmt.Errorf("walking local %s subtree: %s", chainID, werr)
+		}
+		if entry.Mode.IsFile() {
+			if err = replayFile(wt, chainTree, chainID, name); err != nil {
+				return fmt.Errorf("replaying %s/%s: %s", chainID, name, err)
+			}
+		}
+	}
+
+	if _, err = wt.Add(chainID); err != nil {
+		return fmt.Errorf("staging replayed %s subtree: %s", chainID, err)
+	}
+	if _, err = wt.Commit(localCommit.Message, &git.CommitOptions{Author: &localCommit.Author}); err != nil {
+		return fmt.Errorf("re-committing onto rebased head: %s", err)
+	}
+	logger.Info("rebased local commit onto remote head", "remote-hash", remoteRef.Hash().String())
+	return nil
+}
+
+// replayFile writes a single blob from tree at the given path under
+// chainID into the worktree's filesystem.
+func replayFile(wt *git.Worktree, tree *object.Tree, chainID, name string) error {
+	f, err := tree.File(name)
+	if err != nil {
+		return err
+	}
+	contents, err := f.Contents()
+	if err != nil {
+		return err
+	}
+	out, err := wt.Filesystem.Create(path.Join(chainID, name))
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = out.Write([]byte(contents))
+	return err
+}
+
+// authMethod derives a go-git transport.AuthMethod from the configured
+// credentials: an HTTP basic token takes priority over an SSH key path.
+func authMethod(cfg *registrar.Config) (transport.AuthMethod, error) {
+	switch {
+	case cfg.GithubAccessToken != "":
+		return &http.BasicAuth{Username: "x-access-token", Password: cfg.GithubAccessToken}, nil
+	case cfg.SSHKeyPath != "":
+		return ssh.NewPublicKeysFromFile("git", cfg.SSHKeyPath, "")
+	default:
+		return nil, nil
+	}
+}